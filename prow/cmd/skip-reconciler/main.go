@@ -0,0 +1,96 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// skip-reconciler periodically scans open PRs in a configured set of repos
+// and corrects commit statuses written by the skip plugin that no longer
+// match the presubmit they were written for (the job became required, or
+// was deleted from the in-repo config).
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/flagutil"
+	configflagutil "k8s.io/test-infra/prow/flagutil/config"
+	"k8s.io/test-infra/prow/git/v2"
+	"k8s.io/test-infra/prow/logrusutil"
+	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/plugins/skip/reconciler"
+)
+
+type options struct {
+	config       configflagutil.ConfigOptions
+	github       flagutil.GitHubOptions
+	pluginConfig string
+	repos        flagutil.Strings
+	interval     time.Duration
+}
+
+func gatherOptions() options {
+	o := options{repos: flagutil.NewStrings()}
+	fs := flag.NewFlagSet("", flag.ExitOnError)
+	o.config.AddFlags(fs)
+	o.github.AddFlags(fs)
+	fs.StringVar(&o.pluginConfig, "plugin-config", "/etc/plugins/plugins.yaml", "Path to plugin config file, used to resolve trigger policy.")
+	fs.Var(&o.repos, "repo", "Org/repo to reconcile skip statuses for. Can be repeated.")
+	fs.DurationVar(&o.interval, "reconcile-interval", 10*time.Minute, "How often to scan for stale skip statuses.")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		logrus.WithError(err).Fatal("Failed to parse flags")
+	}
+	return o
+}
+
+func main() {
+	logrusutil.ComponentInit()
+	o := gatherOptions()
+
+	configAgent, err := o.config.ConfigAgent()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load config")
+	}
+
+	githubClient, err := o.github.GitHubClient(false)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to construct GitHub client")
+	}
+
+	clientFactory, err := git.NewClientFactory()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to construct git client factory")
+	}
+
+	pluginAgent := &plugins.ConfigAgent{}
+	if err := pluginAgent.Start(o.pluginConfig, nil, false); err != nil {
+		logrus.WithError(err).Fatal("Failed to load plugin config")
+	}
+
+	r := &reconciler.Reconciler{
+		GitHubClient:      githubClient,
+		ConfigAgent:       configAgent,
+		PluginConfigAgent: pluginAgent,
+		GitClient:         clientFactory,
+		Repos:             o.repos.Strings(),
+		Logger:            logrus.WithField("component", "skip-reconciler"),
+	}
+
+	for range time.Tick(o.interval) {
+		r.Run()
+	}
+}
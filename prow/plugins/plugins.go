@@ -0,0 +1,39 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import "fmt"
+
+// Configuration is the in-memory form of the plugins.yaml config file. Only
+// the Skip section is shown here; its many other per-plugin sections
+// (Trigger, Approve, Lgtm, ...) live alongside it in this same struct.
+type Configuration struct {
+	// Skip is the skip plugin's per-org/repo configuration.
+	Skip []Skip `json:"skip,omitempty"`
+}
+
+// Validate returns an error if any configured section is invalid. Skip's
+// validation is wired in here alongside the other per-plugin validations
+// (Trigger, Approve, ...) that already run from this method.
+func (c *Configuration) Validate() error {
+	for _, s := range c.Skip {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("invalid skip config: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reconciler periodically re-verifies statuses written by the skip
+// plugin. If the presubmit a status belongs to has since become required,
+// or has been deleted from the in-repo config, the status it wrote is no
+// longer trustworthy and is reset to pending, with a comment explaining why,
+// rather than left as a stale success.
+package reconciler
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/git/v2"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+	"k8s.io/test-infra/prow/plugins/skip"
+	"k8s.io/test-infra/prow/plugins/trigger"
+)
+
+// filterPresubmits is a variable so tests can stub out trigger's presubmit
+// filtering without depending on its full GitHub-driven behavior.
+var filterPresubmits = trigger.FilterPresubmits
+
+// Reconciler scans open PRs in Repos and corrects statuses the skip plugin
+// wrote that no longer match the presubmit they were written for.
+type Reconciler struct {
+	GitHubClient      skip.GithubClient
+	ConfigAgent       *config.Agent
+	PluginConfigAgent *plugins.ConfigAgent
+	GitClient         git.ClientFactory
+	// Repos are the "org/repo" entries to scan.
+	Repos  []string
+	Logger *logrus.Entry
+}
+
+// Run scans every configured repo once. Callers wire it to a ticker (e.g.
+// in cmd/skip-reconciler) to run it periodically.
+func (r *Reconciler) Run() {
+	for _, orgRepo := range r.Repos {
+		log := r.Logger.WithField("repo", orgRepo)
+		if err := r.reconcileRepo(orgRepo); err != nil {
+			log.WithError(err).Error("Failed to reconcile repo")
+		}
+	}
+}
+
+func (r *Reconciler) reconcileRepo(orgRepo string) error {
+	org, repo, err := splitOrgRepo(orgRepo)
+	if err != nil {
+		return err
+	}
+	prs, err := r.GitHubClient.GetPullRequests(org, repo)
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	cfg := r.ConfigAgent.Config()
+	for _, pr := range prs {
+		log := r.Logger.WithFields(logrus.Fields{"repo": orgRepo, "pr": pr.Number})
+		if err := r.reconcilePR(cfg, org, repo, pr); err != nil {
+			log.WithError(err).Warn("Failed to reconcile pull request")
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcilePR(cfg *config.Config, org, repo string, pr github.PullRequest) error {
+	combined, err := r.GitHubClient.GetCombinedStatus(org, repo, pr.Head.SHA)
+	if err != nil {
+		return fmt.Errorf("failed to get combined status: %w", err)
+	}
+	baseSHAGetter := func() (string, error) { return pr.Base.SHA, nil }
+	headSHAGetter := func() (string, error) { return pr.Head.SHA, nil }
+	presubmits, err := cfg.GetPresubmits(r.GitClient, org+"/"+repo, "", baseSHAGetter, headSHAGetter)
+	if err != nil {
+		return fmt.Errorf("failed to get presubmits: %w", err)
+	}
+
+	var honorOkToTest bool
+	if r.PluginConfigAgent != nil {
+		honorOkToTest = trigger.HonorOkToTest(r.PluginConfigAgent.Config().TriggerFor(org, repo))
+	}
+	filteredPresubmits, err := filterPresubmits(honorOkToTest, r.GitHubClient, "", &pr, presubmits, r.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to filter presubmits: %w", err)
+	}
+
+	return r.resetStaleStatuses(org, repo, pr, combined.Statuses, presubmits, filteredPresubmits)
+}
+
+// resetStaleStatuses inspects every skip-authored status in statuses and
+// resets the ones that are no longer trustworthy: the presubmit they belong
+// to was deleted or has since become required. Statuses of non-required
+// presubmits that trigger is about to re-run are left alone. presubmits is
+// the full set currently resolved from config; filteredPresubmits is the
+// subset trigger would act on right now.
+func (r *Reconciler) resetStaleStatuses(org, repo string, pr github.PullRequest, statuses []github.Status, presubmits, filteredPresubmits []config.Presubmit) error {
+	triggerWillHandle := func(p config.Presubmit) bool {
+		for _, presubmit := range filteredPresubmits {
+			if p.Name == presubmit.Name && p.Context == presubmit.Context {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, status := range statuses {
+		if !skip.IsSkippedDescription(status.Description) {
+			continue
+		}
+		job, stillExists := presubmitFor(presubmits, status.Context)
+		switch {
+		case !stillExists:
+			if err := r.resetStatus(org, repo, pr, status, "the job for it no longer exists in the presubmit config"); err != nil {
+				return err
+			}
+		case job.ContextRequired():
+			if err := r.resetStatus(org, repo, pr, status, "the job for it has since become required"); err != nil {
+				return err
+			}
+		case triggerWillHandle(job):
+			// Trigger is about to re-run and re-report this context on its
+			// own; leave the skip status alone rather than fight it.
+			continue
+		}
+	}
+	return nil
+}
+
+// resetStatus rewrites a stale skip status to pending so the job is made
+// to report for real again, and leaves a comment explaining why.
+func (r *Reconciler) resetStatus(org, repo string, pr github.PullRequest, status github.Status, reason string) error {
+	newStatus := github.Status{
+		State:       github.StatusPending,
+		Description: "Reset by skip-reconciler: " + reason,
+		Context:     status.Context,
+	}
+	if err := r.GitHubClient.CreateStatus(org, repo, pr.Head.SHA, newStatus); err != nil {
+		return fmt.Errorf("failed to reset status for context %s: %w", status.Context, err)
+	}
+	comment := fmt.Sprintf(
+		"Context `%s` was skipped via /skip, but %s, so its status has been reset to pending.",
+		status.Context, reason,
+	)
+	if err := r.GitHubClient.CreateComment(org, repo, pr.Number, comment); err != nil {
+		return fmt.Errorf("failed to comment about reset context %s: %w", status.Context, err)
+	}
+	return nil
+}
+
+func presubmitFor(presubmits []config.Presubmit, context string) (config.Presubmit, bool) {
+	for _, p := range presubmits {
+		if p.Context == context {
+			return p, true
+		}
+	}
+	return config.Presubmit{}, false
+}
+
+func splitOrgRepo(orgRepo string) (string, string, error) {
+	for i := range orgRepo {
+		if orgRepo[i] == '/' {
+			return orgRepo[:i], orgRepo[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("expected org/repo, got %q", orgRepo)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins/skip"
+)
+
+func TestSplitOrgRepo(t *testing.T) {
+	testCases := []struct {
+		in       string
+		wantOrg  string
+		wantRepo string
+		wantErr  bool
+	}{
+		{in: "kubernetes/test-infra", wantOrg: "kubernetes", wantRepo: "test-infra"},
+		{in: "kubernetes", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tc := range testCases {
+		org, repo, err := splitOrgRepo(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("splitOrgRepo(%q): expected error, got none", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("splitOrgRepo(%q): unexpected error: %v", tc.in, err)
+			continue
+		}
+		if org != tc.wantOrg || repo != tc.wantRepo {
+			t.Errorf("splitOrgRepo(%q) = (%q, %q), want (%q, %q)", tc.in, org, repo, tc.wantOrg, tc.wantRepo)
+		}
+	}
+}
+
+func TestPresubmitFor(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "foo"}, Context: "pull-foo-unit"},
+		{JobBase: config.JobBase{Name: "bar"}, Context: "pull-bar-unit"},
+	}
+	if _, ok := presubmitFor(presubmits, "pull-foo-unit"); !ok {
+		t.Errorf("expected pull-foo-unit to be found")
+	}
+	if _, ok := presubmitFor(presubmits, "pull-baz-unit"); ok {
+		t.Errorf("expected pull-baz-unit not to be found")
+	}
+}
+
+// fakeReconcilerClient is a minimal skip.GithubClient stub recording the
+// statuses and comments the reconciler writes back; the other methods are
+// never called by resetStatus/resetStaleStatuses.
+type fakeReconcilerClient struct {
+	skip.GithubClient
+	statuses []github.Status
+	comments []string
+}
+
+func (f *fakeReconcilerClient) CreateStatus(org, repo, ref string, s github.Status) error {
+	f.statuses = append(f.statuses, s)
+	return nil
+}
+
+func (f *fakeReconcilerClient) CreateComment(org, repo string, number int, comment string) error {
+	f.comments = append(f.comments, comment)
+	return nil
+}
+
+func TestResetStatus(t *testing.T) {
+	gc := &fakeReconcilerClient{}
+	r := &Reconciler{GitHubClient: gc}
+	pr := github.PullRequest{Number: 5, Head: github.PullRequestBranch{SHA: "abc123"}}
+	status := github.Status{Context: "pull-foo-unit", Description: "Skipped"}
+
+	if err := r.resetStatus("org", "repo", pr, status, "the job for it has since become required"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gc.statuses) != 1 || gc.statuses[0].Context != "pull-foo-unit" || gc.statuses[0].State != github.StatusPending {
+		t.Errorf("expected a pending status for pull-foo-unit, got %+v", gc.statuses)
+	}
+	if len(gc.comments) != 1 {
+		t.Fatalf("expected one comment, got %d", len(gc.comments))
+	}
+}
+
+func TestResetStaleStatuses(t *testing.T) {
+	pr := github.PullRequest{Number: 5, Head: github.PullRequestBranch{SHA: "abc123"}}
+
+	foo := config.Presubmit{JobBase: config.JobBase{Name: "foo"}, Context: "pull-foo-unit", AlwaysRun: true}
+	bar := config.Presubmit{JobBase: config.JobBase{Name: "bar"}, Context: "pull-bar-unit", Optional: true}
+	baz := config.Presubmit{JobBase: config.JobBase{Name: "baz"}, Context: "pull-baz-unit", Optional: true}
+
+	testCases := []struct {
+		name               string
+		statuses           []github.Status
+		presubmits         []config.Presubmit
+		filteredPresubmits []config.Presubmit
+		wantResetContexts  []string
+	}{
+		{
+			name:              "job deleted from config gets reset",
+			statuses:          []github.Status{{Context: "pull-gone-unit", Description: "Skipped"}},
+			presubmits:        nil,
+			wantResetContexts: []string{"pull-gone-unit"},
+		},
+		{
+			name:              "job since became required gets reset",
+			statuses:          []github.Status{{Context: "pull-foo-unit", Description: "Skipped"}},
+			presubmits:        []config.Presubmit{foo},
+			wantResetContexts: []string{"pull-foo-unit"},
+		},
+		{
+			name:       "still non-required and untouched by trigger is left alone",
+			statuses:   []github.Status{{Context: "pull-bar-unit", Description: "Skipped"}},
+			presubmits: []config.Presubmit{bar},
+		},
+		{
+			name:               "trigger is about to re-run the job is left alone",
+			statuses:           []github.Status{{Context: "pull-baz-unit", Description: "Skipped"}},
+			presubmits:         []config.Presubmit{baz},
+			filteredPresubmits: []config.Presubmit{baz},
+		},
+		{
+			name:     "non-skip statuses are ignored",
+			statuses: []github.Status{{Context: "pull-foo-unit", Description: "failed"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gc := &fakeReconcilerClient{}
+			r := &Reconciler{GitHubClient: gc, Logger: logrus.NewEntry(logrus.New())}
+
+			if err := r.resetStaleStatuses("org", "repo", pr, tc.statuses, tc.presubmits, tc.filteredPresubmits); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			gotContexts := make([]string, len(gc.statuses))
+			for i, s := range gc.statuses {
+				gotContexts[i] = s.Context
+			}
+			if len(gotContexts) != len(tc.wantResetContexts) {
+				t.Fatalf("reset contexts = %v, want %v", gotContexts, tc.wantResetContexts)
+			}
+			for i, want := range tc.wantResetContexts {
+				if gotContexts[i] != want {
+					t.Errorf("reset contexts[%d] = %q, want %q", i, gotContexts[i], want)
+				}
+			}
+		})
+	}
+}
@@ -19,8 +19,10 @@ limitations under the License.
 package skip
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/test-infra/prow/config"
@@ -31,23 +33,156 @@ import (
 	"k8s.io/test-infra/prow/plugins/trigger"
 )
 
-const pluginName = "skip"
+const (
+	pluginName       = "skip"
+	unskipPluginName = "unskip"
+
+	// skippedDescription is the status description written by /skip. It is
+	// kept as a prefix (see skippedDescriptionFor) so that /unskip and the
+	// reconciler can recognize statuses this plugin wrote without clobbering
+	// statuses jobs report for themselves.
+	skippedDescription = "Skipped"
+)
 
 var (
-	skipRe = regexp.MustCompile(`(?mi)^/skip\s*$`)
+	skipRe   = regexp.MustCompile(`(?mi)^/skip(?:[ \t]+(.*?))?\s*$`)
+	unskipRe = regexp.MustCompile(`(?mi)^/unskip(?:[ \t]+(.*?))?\s*$`)
 )
 
-type githubClient interface {
+// getPresubmits and filterPresubmits are variables so tests can exercise
+// handle/handleUnskip against a fake GithubClient without also depending on
+// config.Config's git-backed presubmit resolution or trigger's full
+// GitHub-driven filtering.
+var (
+	getPresubmits = func(c *config.Config, gitClient git.ClientFactory, identifier, baseBranch string, baseSHAGetter, headSHAGetter func() (string, error)) ([]config.Presubmit, error) {
+		return c.GetPresubmits(gitClient, identifier, baseBranch, baseSHAGetter, headSHAGetter)
+	}
+	filterPresubmits = func(honorOkToTest bool, gc GithubClient, body string, pr *github.PullRequest, presubmits []config.Presubmit, log *logrus.Entry) ([]config.Presubmit, error) {
+		return trigger.FilterPresubmits(honorOkToTest, gc, body, pr, presubmits, log)
+	}
+)
+
+// skippedDescriptionFor builds the sentinel status description /skip writes
+// for the given commenter, so /unskip and the reconciler can tell an
+// operator-set success from a real one.
+func skippedDescriptionFor(user string) string {
+	return fmt.Sprintf("%s via /skip by %s", skippedDescription, user)
+}
+
+// IsSkippedDescription reports whether desc was written by the /skip
+// command.
+func IsSkippedDescription(desc string) bool {
+	return strings.HasPrefix(desc, skippedDescription)
+}
+
+// GithubClient is the subset of the GitHub client the skip plugin needs. It
+// is exported so the reconciler subpackage can depend on exactly the same
+// surface rather than defining its own narrower or wider copy.
+type GithubClient interface {
 	CreateComment(owner, repo string, number int, comment string) error
 	CreateStatus(org, repo, ref string, s github.Status) error
 	GetPullRequest(org, repo string, number int) (*github.PullRequest, error)
+	GetPullRequests(org, repo string) ([]github.PullRequest, error)
 	GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error)
 	GetPullRequestChanges(org, repo string, number int) ([]github.PullRequestChange, error)
 	GetRef(org, repo, ref string) (string, error)
+	IsMember(org, user string) (bool, error)
+	ListTeamMembersBySlug(org, teamSlug, role string) ([]github.TeamMember, error)
+}
+
+// isAuthorized reports whether user may run /skip or /unskip in org,
+// according to cfg. An empty cfg (no allowed teams/users and no required
+// membership) authorizes anyone, matching the plugin's historical
+// WhoCanUse: "Anyone" behavior.
+func isAuthorized(gc GithubClient, org, user string, cfg plugins.Skip) (bool, error) {
+	if len(cfg.AllowedUsers) == 0 && len(cfg.AllowedTeams) == 0 && !cfg.RequireMembership {
+		return true, nil
+	}
+	for _, allowed := range cfg.AllowedUsers {
+		if github.NormLogin(allowed) == github.NormLogin(user) {
+			return true, nil
+		}
+	}
+	for _, team := range cfg.AllowedTeams {
+		members, err := gc.ListTeamMembersBySlug(org, team, github.RoleAll)
+		if err != nil {
+			return false, fmt.Errorf("failed to list members of team %s: %w", team, err)
+		}
+		for _, member := range members {
+			if github.NormLogin(member.Login) == github.NormLogin(user) {
+				return true, nil
+			}
+		}
+	}
+	if cfg.RequireMembership {
+		member, err := gc.IsMember(org, user)
+		if err != nil {
+			return false, fmt.Errorf("failed to check org membership for %s: %w", user, err)
+		}
+		return member, nil
+	}
+	return false, nil
+}
+
+// skipAction records one context /skip acted on, for the audit summary
+// comment and for downstream tools (tide, a status-reconciler) that parse
+// the comment's marker block.
+type skipAction struct {
+	Context       string `json:"context"`
+	PreviousState string `json:"previous_state"`
+}
+
+// skipSummaryMarker is the machine-parseable payload embedded as an HTML
+// comment in the audit summary so downstream tools can reconstruct history
+// without scraping the human-readable text.
+type skipSummaryMarker struct {
+	User    string       `json:"user"`
+	SHA     string       `json:"sha"`
+	Actions []skipAction `json:"actions"`
+}
+
+// formatSkipSummary builds the human-readable summary of what /skip did,
+// followed by a `skip-plugin` HTML comment marker carrying the same
+// information as JSON.
+func formatSkipSummary(user, sha string, actions []skipAction) string {
+	var body string
+	if len(actions) == 0 {
+		body = fmt.Sprintf("No non-required failing contexts to skip on commit %s.", sha)
+	} else {
+		body = fmt.Sprintf("Skipped %d context(s) on commit %s:\n", len(actions), sha)
+		for _, a := range actions {
+			body += fmt.Sprintf("- `%s` (was `%s`)\n", a.Context, a.PreviousState)
+		}
+	}
+	marker, err := json.Marshal(skipSummaryMarker{User: user, SHA: sha, Actions: actions})
+	if err != nil {
+		marker = []byte("{}")
+	}
+	return fmt.Sprintf("%s\n<!-- skip-plugin:%s -->", body, marker)
+}
+
+// unauthorizedResponse formats the comment posted when user is not allowed
+// to run /skip or /unskip under cfg.
+func unauthorizedResponse(user string, cfg plugins.Skip) string {
+	resp := fmt.Sprintf("%s is not authorized to run /skip or /unskip in this repo.", user)
+	if len(cfg.AllowedUsers) > 0 || len(cfg.AllowedTeams) > 0 {
+		resp += " Allowed:"
+		for _, u := range cfg.AllowedUsers {
+			resp += fmt.Sprintf(" user:%s", u)
+		}
+		for _, t := range cfg.AllowedTeams {
+			resp += fmt.Sprintf(" team:%s", t)
+		}
+	}
+	if cfg.RequireMembership {
+		resp += " Org members are also allowed."
+	}
+	return resp
 }
 
 func init() {
 	plugins.RegisterGenericCommentHandler(pluginName, handleGenericComment, helpProvider)
+	plugins.RegisterGenericCommentHandler(unskipPluginName, handleUnskipGenericComment, unskipHelpProvider)
 }
 
 func helpProvider(config *plugins.Configuration, _ []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
@@ -55,21 +190,79 @@ func helpProvider(config *plugins.Configuration, _ []config.OrgRepo) (*pluginhel
 		Description: "The skip plugin allows users to clean up GitHub stale commit statuses for non-blocking jobs on a PR.",
 	}
 	pluginHelp.AddCommand(pluginhelp.Command{
-		Usage:       "/skip",
-		Description: "Cleans up GitHub stale commit statuses for non-blocking jobs on a PR.",
+		Usage:       "/skip [<context>|re:<regex>]...",
+		Description: "Cleans up GitHub stale commit statuses for non-blocking jobs on a PR. With no arguments, every eligible context is skipped. Given one or more context names or `re:` regexes (space-separated; the command can be repeated on several lines), only the matching contexts are skipped.",
+		Featured:    false,
+		WhoCanUse:   "Anyone can trigger this command on a PR, unless the repo's Skip plugin config restricts it to specific users or teams.",
+		Examples:    []string{"/skip", "/skip pull-foo-unit", "/skip re:^pull-foo-.*$"},
+	})
+	return pluginHelp, nil
+}
+
+func unskipHelpProvider(config *plugins.Configuration, _ []config.OrgRepo) (*pluginhelp.PluginHelp, error) {
+	pluginHelp := &pluginhelp.PluginHelp{
+		Description: "The unskip plugin reverts a prior /skip, putting commit statuses it cleaned up back into a pending state.",
+	}
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/unskip [<context>|re:<regex>]...",
+		Description: "Reverts a prior /skip, putting the matching contexts back into a pending state so their jobs report again. With no arguments, every context previously skipped by this plugin is reverted.",
 		Featured:    false,
-		WhoCanUse:   "Anyone can trigger this command on a PR.",
-		Examples:    []string{"/skip"},
+		WhoCanUse:   "Anyone can trigger this command on a PR, unless the repo's Skip plugin config restricts it to specific users or teams.",
+		Examples:    []string{"/unskip", "/unskip pull-foo-unit", "/unskip re:^pull-foo-.*$"},
 	})
 	return pluginHelp, nil
 }
 
+// parseArgs collects the context names and regexes requested across all
+// lines of body matching re. A bare command with no arguments on any line
+// is reported as matchAll so callers can preserve the original
+// match-everything-eligible behavior.
+func parseArgs(re *regexp.Regexp, body string) (contexts []string, regexes []*regexp.Regexp, matchAll bool, err error) {
+	matches := re.FindAllStringSubmatch(body, -1)
+	for _, match := range matches {
+		for _, arg := range strings.Fields(match[1]) {
+			if strings.HasPrefix(arg, "re:") {
+				pattern := strings.TrimPrefix(arg, "re:")
+				compiled, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, nil, false, fmt.Errorf("invalid regexp %q: %w", pattern, err)
+				}
+				regexes = append(regexes, compiled)
+				continue
+			}
+			contexts = append(contexts, arg)
+		}
+	}
+	return contexts, regexes, len(contexts) == 0 && len(regexes) == 0, nil
+}
+
+// matchesArgs reports whether context should be acted on given the
+// arguments parsed from the triggering comment. matchAll makes every
+// context match, preserving the behavior of a bare /skip or /unskip.
+func matchesArgs(context string, contexts []string, regexes []*regexp.Regexp, matchAll bool) bool {
+	if matchAll {
+		return true
+	}
+	for _, c := range contexts {
+		if c == context {
+			return true
+		}
+	}
+	for _, re := range regexes {
+		if re.MatchString(context) {
+			return true
+		}
+	}
+	return false
+}
+
 func handleGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error {
 	honorOkToTest := trigger.HonorOkToTest(pc.PluginConfig.TriggerFor(e.Repo.Owner.Login, e.Repo.Name))
-	return handle(pc.GitHubClient, pc.Logger, &e, pc.Config, pc.GitClient, honorOkToTest)
+	cfg := pc.PluginConfig.SkipFor(e.Repo.Owner.Login, e.Repo.Name)
+	return handle(pc.GitHubClient, pc.Logger, &e, pc.Config, pc.GitClient, honorOkToTest, cfg)
 }
 
-func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c *config.Config, gitClient git.ClientFactory, honorOkToTest bool) error {
+func handle(gc GithubClient, log *logrus.Entry, e *github.GenericCommentEvent, c *config.Config, gitClient git.ClientFactory, honorOkToTest bool, cfg plugins.Skip) error {
 	if !e.IsPR || e.IssueState != "open" || e.Action != github.GenericCommentActionCreated {
 		return nil
 	}
@@ -82,6 +275,21 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 	repo := e.Repo.Name
 	number := e.Number
 
+	authorized, err := isAuthorized(gc, org, e.User.Login, cfg)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, unauthorizedResponse(e.User.Login, cfg)))
+	}
+
+	contexts, regexes, matchAll, err := parseArgs(skipRe, e.Body)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot parse /skip arguments: %v", err)
+		log.Warn(resp)
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+	}
+
 	pr, err := gc.GetPullRequest(org, repo, number)
 	if err != nil {
 		resp := fmt.Sprintf("Cannot get PR #%d in %s/%s: %v", number, org, repo, err)
@@ -98,7 +306,7 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 	headSHAGetter := func() (string, error) {
 		return pr.Head.SHA, nil
 	}
-	presubmits, err := c.GetPresubmits(gitClient, org+"/"+repo, "", baseSHAGetter, headSHAGetter)
+	presubmits, err := getPresubmits(c, gitClient, org+"/"+repo, "", baseSHAGetter, headSHAGetter)
 	if err != nil {
 		return fmt.Errorf("failed to get presubmits: %w", err)
 	}
@@ -114,7 +322,7 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 	}
 	statuses := combinedStatus.Statuses
 
-	filteredPresubmits, err := trigger.FilterPresubmits(honorOkToTest, gc, e.Body, pr, presubmits, log)
+	filteredPresubmits, err := filterPresubmits(honorOkToTest, gc, e.Body, pr, presubmits, log)
 	if err != nil {
 		resp := fmt.Sprintf("Cannot get combined status for PR #%d in %s/%s: %v", number, org, repo, err)
 		log.Warn(resp)
@@ -129,6 +337,7 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 		return false
 	}
 
+	var actions []skipAction
 	for _, job := range presubmits {
 		// Only consider jobs that have already posted a failed status
 		if !statusExists(job, statuses) || isSuccess(job, statuses) {
@@ -147,10 +356,24 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 		if job.ContextRequired() {
 			continue
 		}
+		// Protected contexts can never be skipped, even by an authorized
+		// user, regardless of whether the job itself is required.
+		if cfg.IsProtectedContext(job.Context) {
+			continue
+		}
+		// When arguments were given, only act on the contexts/regexes
+		// the user asked for; a bare /skip still matches everything.
+		if !matchesArgs(job.Context, contexts, regexes, matchAll) {
+			continue
+		}
 		context := job.Context
+		previousState := ""
+		if prev, ok := statusFor(job, statuses); ok {
+			previousState = string(prev.State)
+		}
 		status := github.Status{
 			State:       github.StatusSuccess,
-			Description: "Skipped",
+			Description: skippedDescriptionFor(e.User.Login),
 			Context:     context,
 		}
 		if err := gc.CreateStatus(org, repo, pr.Head.SHA, status); err != nil {
@@ -158,6 +381,120 @@ func handle(gc githubClient, log *logrus.Entry, e *github.GenericCommentEvent, c
 			log.Warn(resp)
 			return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
 		}
+		actions = append(actions, skipAction{Context: context, PreviousState: previousState})
+	}
+
+	if cfg.Quiet {
+		return nil
+	}
+	summary := formatSkipSummary(e.User.Login, pr.Head.SHA, actions)
+	return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, summary))
+}
+
+func handleUnskipGenericComment(pc plugins.Agent, e github.GenericCommentEvent) error {
+	honorOkToTest := trigger.HonorOkToTest(pc.PluginConfig.TriggerFor(e.Repo.Owner.Login, e.Repo.Name))
+	cfg := pc.PluginConfig.SkipFor(e.Repo.Owner.Login, e.Repo.Name)
+	return handleUnskip(pc.GitHubClient, pc.Logger, &e, pc.Config, pc.GitClient, honorOkToTest, cfg)
+}
+
+// handleUnskip reverts contexts previously marked Skipped by this plugin,
+// putting them back into a pending state so their jobs report again.
+func handleUnskip(gc GithubClient, log *logrus.Entry, e *github.GenericCommentEvent, c *config.Config, gitClient git.ClientFactory, honorOkToTest bool, cfg plugins.Skip) error {
+	if !e.IsPR || e.IssueState != "open" || e.Action != github.GenericCommentActionCreated {
+		return nil
+	}
+
+	if !unskipRe.MatchString(e.Body) {
+		return nil
+	}
+
+	org := e.Repo.Owner.Login
+	repo := e.Repo.Name
+	number := e.Number
+
+	authorized, err := isAuthorized(gc, org, e.User.Login, cfg)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, unauthorizedResponse(e.User.Login, cfg)))
+	}
+
+	contexts, regexes, matchAll, err := parseArgs(unskipRe, e.Body)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot parse /unskip arguments: %v", err)
+		log.Warn(resp)
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+	}
+
+	pr, err := gc.GetPullRequest(org, repo, number)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot get PR #%d in %s/%s: %v", number, org, repo, err)
+		log.Warn(resp)
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+	}
+	baseSHAGetter := func() (string, error) {
+		baseSHA, err := gc.GetRef(org, repo, "heads/"+pr.Base.Ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to get baseSHA: %w", err)
+		}
+		return baseSHA, nil
+	}
+	headSHAGetter := func() (string, error) {
+		return pr.Head.SHA, nil
+	}
+	presubmits, err := getPresubmits(c, gitClient, org+"/"+repo, "", baseSHAGetter, headSHAGetter)
+	if err != nil {
+		return fmt.Errorf("failed to get presubmits: %w", err)
+	}
+
+	combinedStatus, err := gc.GetCombinedStatus(org, repo, pr.Head.SHA)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot get combined commit statuses for PR #%d in %s/%s: %v", number, org, repo, err)
+		log.Warn(resp)
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+	}
+	statuses := combinedStatus.Statuses
+
+	filteredPresubmits, err := filterPresubmits(honorOkToTest, gc, e.Body, pr, presubmits, log)
+	if err != nil {
+		resp := fmt.Sprintf("Cannot get combined status for PR #%d in %s/%s: %v", number, org, repo, err)
+		log.Warn(resp)
+		return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+	}
+	triggerWillHandle := func(p config.Presubmit) bool {
+		for _, presubmit := range filteredPresubmits {
+			if p.Name == presubmit.Name && p.Context == presubmit.Context {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, job := range presubmits {
+		status, ok := statusFor(job, statuses)
+		// Only revert contexts this plugin previously skipped.
+		if !ok || !IsSkippedDescription(status.Description) {
+			continue
+		}
+		// Leave jobs the trigger plugin will re-run for this same
+		// comment alone; it will overwrite the status itself.
+		if triggerWillHandle(job) {
+			continue
+		}
+		if !matchesArgs(job.Context, contexts, regexes, matchAll) {
+			continue
+		}
+		newStatus := github.Status{
+			State:       github.StatusPending,
+			Description: "Unskipped",
+			Context:     job.Context,
+		}
+		if err := gc.CreateStatus(org, repo, pr.Head.SHA, newStatus); err != nil {
+			resp := fmt.Sprintf("Cannot update PR status for context %s: %v", job.Context, err)
+			log.Warn(resp)
+			return gc.CreateComment(org, repo, number, plugins.FormatResponseRaw(e.Body, e.HTMLURL, e.User.Login, resp))
+		}
 	}
 	return nil
 }
@@ -179,3 +516,19 @@ func isSuccess(job config.Presubmit, statuses []github.Status) bool {
 	}
 	return false
 }
+
+// statusFor returns the status reported for job's context, if any.
+func statusFor(job config.Presubmit, statuses []github.Status) (github.Status, bool) {
+	return StatusForContext(job.Context, statuses)
+}
+
+// StatusForContext returns the status reported for the given context, if
+// any.
+func StatusForContext(context string, statuses []github.Status) (github.Status, bool) {
+	for _, status := range statuses {
+		if status.Context == context {
+			return status, true
+		}
+	}
+	return github.Status{}, false
+}
@@ -0,0 +1,489 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skip
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/test-infra/prow/config"
+	"k8s.io/test-infra/prow/git/v2"
+	"k8s.io/test-infra/prow/github"
+	"k8s.io/test-infra/prow/plugins"
+)
+
+func TestParseSkipArgs(t *testing.T) {
+	testCases := []struct {
+		name         string
+		body         string
+		wantContexts []string
+		wantRegexes  []string
+		wantMatchAll bool
+		wantErr      bool
+	}{
+		{
+			name:         "bare /skip matches everything",
+			body:         "/skip",
+			wantMatchAll: true,
+		},
+		{
+			name:         "literal context",
+			body:         "/skip pull-foo-unit",
+			wantContexts: []string{"pull-foo-unit"},
+		},
+		{
+			name:        "regex",
+			body:        "/skip re:^pull-foo-.*$",
+			wantRegexes: []string{"^pull-foo-.*$"},
+		},
+		{
+			name:         "mixed literal and regex, space separated",
+			body:         "/skip pull-foo-unit re:^pull-bar-.*$",
+			wantContexts: []string{"pull-foo-unit"},
+			wantRegexes:  []string{"^pull-bar-.*$"},
+		},
+		{
+			name:         "repeated /skip lines are combined",
+			body:         "/skip pull-foo-unit\n/skip re:^pull-bar-.*$",
+			wantContexts: []string{"pull-foo-unit"},
+			wantRegexes:  []string{"^pull-bar-.*$"},
+		},
+		{
+			name:    "invalid regex",
+			body:    "/skip re:(unclosed",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			contexts, regexes, matchAll, err := parseArgs(skipRe, tc.body)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matchAll != tc.wantMatchAll {
+				t.Errorf("matchAll: got %v, want %v", matchAll, tc.wantMatchAll)
+			}
+			if len(contexts) != len(tc.wantContexts) {
+				t.Fatalf("contexts: got %v, want %v", contexts, tc.wantContexts)
+			}
+			for i, c := range contexts {
+				if c != tc.wantContexts[i] {
+					t.Errorf("contexts[%d]: got %q, want %q", i, c, tc.wantContexts[i])
+				}
+			}
+			if len(regexes) != len(tc.wantRegexes) {
+				t.Fatalf("regexes: got %v, want %v", regexes, tc.wantRegexes)
+			}
+			for i, re := range regexes {
+				if re.String() != tc.wantRegexes[i] {
+					t.Errorf("regexes[%d]: got %q, want %q", i, re.String(), tc.wantRegexes[i])
+				}
+			}
+		})
+	}
+}
+
+// TestSkipCommandRegexes guards against skipRe/unskipRe matching commands
+// that merely start with "/skip" or "/unskip" followed by more non-space
+// characters, e.g. "/skipped-job" or "/unskippable", which are not
+// invocations of either command.
+func TestSkipCommandRegexes(t *testing.T) {
+	testCases := []struct {
+		name string
+		re   *regexp.Regexp
+		body string
+		want bool
+	}{
+		{name: "bare /skip matches", re: skipRe, body: "/skip", want: true},
+		{name: "/skip with args matches", re: skipRe, body: "/skip pull-foo-unit", want: true},
+		{name: "/skipped-job does not match", re: skipRe, body: "/skipped-job", want: false},
+		{name: "/skippy does not match", re: skipRe, body: "/skippy", want: false},
+		{name: "/skip-reconciler does not match", re: skipRe, body: "/skip-reconciler", want: false},
+		{name: "bare /unskip matches", re: unskipRe, body: "/unskip", want: true},
+		{name: "/unskip with args matches", re: unskipRe, body: "/unskip pull-foo-unit", want: true},
+		{name: "/unskippable does not match", re: unskipRe, body: "/unskippable", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.re.MatchString(tc.body); got != tc.want {
+				t.Errorf("%q.MatchString(%q) = %v, want %v", tc.re, tc.body, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchesSkipArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		context  string
+		contexts []string
+		regexes  []string
+		matchAll bool
+		want     bool
+	}{
+		{
+			name:     "matchAll matches anything",
+			context:  "pull-foo-unit",
+			matchAll: true,
+			want:     true,
+		},
+		{
+			name:     "literal match",
+			context:  "pull-foo-unit",
+			contexts: []string{"pull-foo-unit"},
+			want:     true,
+		},
+		{
+			name:     "literal mismatch",
+			context:  "pull-foo-unit",
+			contexts: []string{"pull-bar-unit"},
+			want:     false,
+		},
+		{
+			name:    "regex match",
+			context: "pull-foo-unit",
+			regexes: []string{"^pull-foo-.*$"},
+			want:    true,
+		},
+		{
+			name:    "regex mismatch",
+			context: "pull-foo-unit",
+			regexes: []string{"^pull-bar-.*$"},
+			want:    false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, regexes, _, err := parseArgs(skipRe, regexArgsBody(tc.regexes))
+			if err != nil {
+				t.Fatalf("failed to build regexes: %v", err)
+			}
+			got := matchesArgs(tc.context, tc.contexts, regexes, tc.matchAll)
+			if got != tc.want {
+				t.Errorf("matchesArgs: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// regexArgsBody builds a /skip comment body out of re: arguments so tests
+// can reuse parseArgs to compile them.
+func regexArgsBody(regexes []string) string {
+	body := "/skip"
+	for _, re := range regexes {
+		body += " re:" + re
+	}
+	return body
+}
+
+func TestSkippedDescription(t *testing.T) {
+	desc := skippedDescriptionFor("alice")
+	if !IsSkippedDescription(desc) {
+		t.Errorf("IsSkippedDescription(%q) = false, want true", desc)
+	}
+	if IsSkippedDescription("some-job failed") {
+		t.Errorf("IsSkippedDescription(%q) = true, want false", "some-job failed")
+	}
+}
+
+// fakeAuthClient is a minimal GithubClient stub for exercising isAuthorized;
+// the other methods are never called by it.
+type fakeAuthClient struct {
+	GithubClient
+	orgMembers map[string]bool
+	teams      map[string][]github.TeamMember
+}
+
+func (f *fakeAuthClient) IsMember(org, user string) (bool, error) {
+	return f.orgMembers[user], nil
+}
+
+func (f *fakeAuthClient) ListTeamMembersBySlug(org, teamSlug, role string) ([]github.TeamMember, error) {
+	return f.teams[teamSlug], nil
+}
+
+func TestIsAuthorized(t *testing.T) {
+	gc := &fakeAuthClient{
+		orgMembers: map[string]bool{"member": true},
+		teams: map[string][]github.TeamMember{
+			"reviewers": {{Login: "teammate"}},
+		},
+	}
+	testCases := []struct {
+		name string
+		user string
+		cfg  plugins.Skip
+		want bool
+	}{
+		{
+			name: "no restrictions allows anyone",
+			user: "rando",
+			want: true,
+		},
+		{
+			name: "allowed user",
+			user: "alice",
+			cfg:  plugins.Skip{AllowedUsers: []string{"alice"}},
+			want: true,
+		},
+		{
+			name: "user not in allow list",
+			user: "mallory",
+			cfg:  plugins.Skip{AllowedUsers: []string{"alice"}},
+			want: false,
+		},
+		{
+			name: "team member allowed",
+			user: "teammate",
+			cfg:  plugins.Skip{AllowedTeams: []string{"reviewers"}},
+			want: true,
+		},
+		{
+			name: "non team member rejected",
+			user: "rando",
+			cfg:  plugins.Skip{AllowedTeams: []string{"reviewers"}},
+			want: false,
+		},
+		{
+			name: "org membership allowed",
+			user: "member",
+			cfg:  plugins.Skip{RequireMembership: true},
+			want: true,
+		},
+		{
+			name: "non-member rejected despite require_membership",
+			user: "rando",
+			cfg:  plugins.Skip{RequireMembership: true},
+			want: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := isAuthorized(gc, "org", tc.user, tc.cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("isAuthorized(%q) = %v, want %v", tc.user, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatSkipSummary(t *testing.T) {
+	empty := formatSkipSummary("alice", "abc123", nil)
+	if !strings.Contains(empty, "No non-required failing contexts to skip") {
+		t.Errorf("expected empty-summary message, got %q", empty)
+	}
+	if !strings.Contains(empty, "<!-- skip-plugin:") {
+		t.Errorf("expected marker comment, got %q", empty)
+	}
+
+	withActions := formatSkipSummary("alice", "abc123", []skipAction{
+		{Context: "pull-foo-unit", PreviousState: "failure"},
+	})
+	if !strings.Contains(withActions, "pull-foo-unit") || !strings.Contains(withActions, "failure") {
+		t.Errorf("expected summary to mention context and previous state, got %q", withActions)
+	}
+	if !strings.Contains(withActions, `"user":"alice"`) || !strings.Contains(withActions, `"sha":"abc123"`) {
+		t.Errorf("expected marker JSON to carry user and sha, got %q", withActions)
+	}
+}
+
+func TestSkipIsProtectedContext(t *testing.T) {
+	cfg := plugins.Skip{ProtectedContexts: []string{"pull-foo-*"}}
+	if !cfg.IsProtectedContext("pull-foo-unit") {
+		t.Errorf("expected pull-foo-unit to be protected")
+	}
+	if cfg.IsProtectedContext("pull-bar-unit") {
+		t.Errorf("expected pull-bar-unit not to be protected")
+	}
+}
+
+// fakeHandleClient is a GithubClient stub covering what handle and
+// handleUnskip touch, except the team and org membership lookups, recording
+// the statuses and comments they write back.
+type fakeHandleClient struct {
+	GithubClient
+	pr       *github.PullRequest
+	combined *github.CombinedStatus
+	statuses []github.Status
+	comments []string
+}
+
+func (f *fakeHandleClient) GetPullRequest(org, repo string, number int) (*github.PullRequest, error) {
+	return f.pr, nil
+}
+
+func (f *fakeHandleClient) GetRef(org, repo, ref string) (string, error) {
+	return "base-sha", nil
+}
+
+func (f *fakeHandleClient) GetCombinedStatus(org, repo, ref string) (*github.CombinedStatus, error) {
+	return f.combined, nil
+}
+
+func (f *fakeHandleClient) CreateStatus(org, repo, ref string, s github.Status) error {
+	f.statuses = append(f.statuses, s)
+	return nil
+}
+
+func (f *fakeHandleClient) CreateComment(org, repo string, number int, comment string) error {
+	f.comments = append(f.comments, comment)
+	return nil
+}
+
+// stubPresubmits replaces getPresubmits/filterPresubmits for the duration of
+// a test so handle/handleUnskip can be exercised without config.Config's
+// git-backed resolution or trigger's full GitHub-driven filtering.
+func stubPresubmits(t *testing.T, presubmits, handledByTrigger []config.Presubmit) {
+	t.Helper()
+	origGet, origFilter := getPresubmits, filterPresubmits
+	getPresubmits = func(*config.Config, git.ClientFactory, string, string, func() (string, error), func() (string, error)) ([]config.Presubmit, error) {
+		return presubmits, nil
+	}
+	filterPresubmits = func(bool, GithubClient, string, *github.PullRequest, []config.Presubmit, *logrus.Entry) ([]config.Presubmit, error) {
+		return handledByTrigger, nil
+	}
+	t.Cleanup(func() {
+		getPresubmits, filterPresubmits = origGet, origFilter
+	})
+}
+
+func newTestEvent(body, user string) *github.GenericCommentEvent {
+	e := &github.GenericCommentEvent{
+		IsPR:   true,
+		Action: github.GenericCommentActionCreated,
+		Body:   body,
+	}
+	e.IssueState = "open"
+	e.Repo.Owner.Login = "org"
+	e.Repo.Name = "repo"
+	e.Number = 5
+	e.User.Login = user
+	return e
+}
+
+func TestHandleRejectsUnauthorizedUser(t *testing.T) {
+	gc := &fakeHandleClient{}
+	cfg := plugins.Skip{AllowedUsers: []string{"alice"}}
+	e := newTestEvent("/skip", "mallory")
+
+	if err := handle(gc, logrus.NewEntry(logrus.New()), e, &config.Config{}, nil, false, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gc.statuses) != 0 {
+		t.Errorf("expected no statuses written for an unauthorized user, got %+v", gc.statuses)
+	}
+	if len(gc.comments) != 1 || !strings.Contains(gc.comments[0], "not authorized") {
+		t.Errorf("expected an unauthorized-response comment, got %+v", gc.comments)
+	}
+}
+
+func TestHandleSkipsProtectedContext(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "foo"}, Context: "pull-foo-unit", Optional: true},
+		{JobBase: config.JobBase{Name: "protected"}, Context: "pull-protected-unit", Optional: true},
+	}
+	stubPresubmits(t, presubmits, nil)
+
+	gc := &fakeHandleClient{
+		pr: &github.PullRequest{Head: github.PullRequestBranch{SHA: "head-sha"}},
+		combined: &github.CombinedStatus{
+			State: github.StatusFailure,
+			Statuses: []github.Status{
+				{Context: "pull-foo-unit", State: github.StatusFailure},
+				{Context: "pull-protected-unit", State: github.StatusFailure},
+			},
+		},
+	}
+	cfg := plugins.Skip{ProtectedContexts: []string{"pull-protected-*"}}
+	e := newTestEvent("/skip", "anyone")
+
+	if err := handle(gc, logrus.NewEntry(logrus.New()), e, &config.Config{}, nil, false, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gc.statuses) != 1 || gc.statuses[0].Context != "pull-foo-unit" {
+		t.Errorf("expected only pull-foo-unit to be skipped, got %+v", gc.statuses)
+	}
+}
+
+func TestHandleQuietSuppressesSummary(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "foo"}, Context: "pull-foo-unit", Optional: true},
+	}
+	stubPresubmits(t, presubmits, nil)
+
+	gc := &fakeHandleClient{
+		pr: &github.PullRequest{Head: github.PullRequestBranch{SHA: "head-sha"}},
+		combined: &github.CombinedStatus{
+			State:    github.StatusFailure,
+			Statuses: []github.Status{{Context: "pull-foo-unit", State: github.StatusFailure}},
+		},
+	}
+	cfg := plugins.Skip{Quiet: true}
+	e := newTestEvent("/skip", "anyone")
+
+	if err := handle(gc, logrus.NewEntry(logrus.New()), e, &config.Config{}, nil, false, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gc.statuses) != 1 {
+		t.Fatalf("expected the context to still be skipped, got %+v", gc.statuses)
+	}
+	if len(gc.comments) != 0 {
+		t.Errorf("expected quiet to suppress the summary comment, got %+v", gc.comments)
+	}
+}
+
+func TestHandleUnskipLeavesTriggerHandledAlone(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "skipped"}, Context: "pull-skipped-unit"},
+		{JobBase: config.JobBase{Name: "retriggered"}, Context: "pull-retriggered-unit"},
+	}
+	handledByTrigger := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "retriggered"}, Context: "pull-retriggered-unit"},
+	}
+	stubPresubmits(t, presubmits, handledByTrigger)
+
+	gc := &fakeHandleClient{
+		pr: &github.PullRequest{Head: github.PullRequestBranch{SHA: "head-sha"}},
+		combined: &github.CombinedStatus{
+			Statuses: []github.Status{
+				{Context: "pull-skipped-unit", Description: skippedDescriptionFor("bob")},
+				{Context: "pull-retriggered-unit", Description: skippedDescriptionFor("bob")},
+				{Context: "pull-untouched-unit", Description: "failed"},
+			},
+		},
+	}
+	e := newTestEvent("/unskip", "bob")
+
+	if err := handleUnskip(gc, logrus.NewEntry(logrus.New()), e, &config.Config{}, nil, false, plugins.Skip{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gc.statuses) != 1 || gc.statuses[0].Context != "pull-skipped-unit" {
+		t.Errorf("expected only pull-skipped-unit to be reverted, got %+v", gc.statuses)
+	}
+}
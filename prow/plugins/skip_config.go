@@ -0,0 +1,90 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugins
+
+import (
+	"fmt"
+	"path"
+)
+
+// Skip is the config for the skip plugin's authorization policy. It is
+// resolved per org/repo the same way Trigger is, via SkipFor. Configuration
+// gains a `Skip []Skip` field alongside its other per-plugin sections.
+type Skip struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// AllowedTeams are the GitHub team slugs, within the org the command was
+	// run in, whose members may run /skip and /unskip.
+	AllowedTeams []string `json:"allowed_teams,omitempty"`
+	// AllowedUsers are GitHub logins, in addition to AllowedTeams and
+	// RequireMembership, that may run /skip and /unskip.
+	AllowedUsers []string `json:"allowed_users,omitempty"`
+	// RequireMembership, if set, allows any member of the org the command
+	// was run in to use /skip and /unskip even if they are in none of
+	// AllowedTeams or AllowedUsers.
+	RequireMembership bool `json:"require_membership,omitempty"`
+	// ProtectedContexts are globs (matched with path.Match) of contexts that
+	// can never be skipped, even by an otherwise-authorized user and even
+	// when the underlying job is not required.
+	ProtectedContexts []string `json:"protected_contexts,omitempty"`
+	// Quiet suppresses the audit summary comment /skip posts after acting
+	// on a PR.
+	Quiet bool `json:"quiet,omitempty"`
+}
+
+// SkipFor finds the Skip config for a repo, if one exists. A configuration
+// for repo takes precedence over a configuration for the org.
+func (c *Configuration) SkipFor(org, repo string) Skip {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	var orgConfig, repoConfig Skip
+	for _, skip := range c.Skip {
+		for _, r := range skip.Repos {
+			if r == org {
+				orgConfig = skip
+			}
+			if r == fullName {
+				repoConfig = skip
+			}
+		}
+	}
+	if len(repoConfig.Repos) > 0 {
+		return repoConfig
+	}
+	return orgConfig
+}
+
+// IsProtectedContext reports whether context matches one of the
+// ProtectedContexts globs, and therefore must never be skipped.
+func (s Skip) IsProtectedContext(context string) bool {
+	for _, glob := range s.ProtectedContexts {
+		if ok, err := path.Match(glob, context); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the Skip config is malformed, e.g. if one of
+// its ProtectedContexts globs cannot be compiled.
+func (s Skip) Validate() error {
+	for _, glob := range s.ProtectedContexts {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid protected_contexts glob %q: %w", glob, err)
+		}
+	}
+	return nil
+}